@@ -4,6 +4,7 @@ import (
 	"encoding/binary"
 	"fmt"
 	"io"
+	"math/rand"
 
 	"github.com/james-bowman/sparse"
 	"gonum.org/v1/gonum/mat"
@@ -12,6 +13,18 @@ import (
 // TruncatedSVD implements the Singular Value Decomposition factorisation of matrices.
 // This produces an approximation of the input matrix at a lower rank. This is a core
 // component of LSA (Latent Semantic Analsis)
+//
+// By default, Fit/FitTransform factorise the whole input matrix using gonum's dense
+// SVD (Method == FullSVD). This is exact but requires materialising dense U and V
+// factors so is impractical for very large or wide term-document matrices. Setting
+// Method to RandomizedSVD instead approximates the truncated factorisation using the
+// randomized range finder algorithm of Halko, Martinsson and Tropp, which only ever
+// multiplies against the original (possibly sparse) matrix and so scales to corpora
+// FullSVD cannot handle.
+//
+// Once fitted (by either Fit or FitTransform), PartialFit can be used to extend the
+// factorisation with additional mini-batches of documents, for streaming/online
+// pipelines that cannot hold the whole corpus in memory at once.
 type TruncatedSVD struct {
 	// Components is the truncated term matrix (matrix U of the Singular Value Decomposition
 	// (A=USV^T)). The matrix will be of size m, k where m = the number of unique terms
@@ -26,14 +39,77 @@ type TruncatedSVD struct {
 	// input matrix and min(m, n, K) is the lowest value of m, n, K where m is the number of
 	// rows in the original, input matrix.
 	K int
+
+	// Method selects the factorisation algorithm used by Fit/FitTransform. The zero
+	// value, FullSVD, is exact. RandomizedSVD trades a small, tunable amount of
+	// accuracy for the ability to factorise much larger matrices.
+	Method SVDMethod
+
+	// Oversampling is the number of extra random dimensions (p) sampled beyond K
+	// when Method is RandomizedSVD, improving the accuracy of the approximation.
+	// Ignored for FullSVD. Defaults to 10 if <= 0.
+	Oversampling int
+
+	// PowerIterations is the number of power iterations performed to sharpen the
+	// random sketch when Method is RandomizedSVD, improving accuracy for matrices
+	// whose singular values decay slowly. Ignored for FullSVD.
+	PowerIterations int
+
+	// sigma and v retain the singular values and right singular vectors alongside
+	// Components (U) so that PartialFit can extend the factorisation with
+	// additional batches without re-factorising previously seen data.
+	sigma []float64
+	v     *mat.Dense
 }
 
+// SVDMethod specifies the algorithm used by TruncatedSVD to factorise the input
+// matrix.
+type SVDMethod int
+
+const (
+	// FullSVD factorises the whole input matrix using gonum's dense SVD. This is
+	// exact but requires materialising dense factors.
+	FullSVD SVDMethod = iota
+
+	// RandomizedSVD approximates the truncated SVD using the randomized range
+	// finder algorithm of Halko, Martinsson and Tropp, see TruncatedSVD.Method
+	// for details.
+	RandomizedSVD
+)
+
+// defaultOversampling is the number of extra random dimensions sampled beyond K
+// by RandomizedSVD when Oversampling is left unset.
+const defaultOversampling = 10
+
+// Assert that TruncatedSVD and SignRandomProjection satisfy Serialisable, the
+// shared interface their Save/Load methods are intended to implement.
+var (
+	_ Serialisable = (*TruncatedSVD)(nil)
+	_ Serialisable = (*SignRandomProjection)(nil)
+)
+
 // NewTruncatedSVD creates a new TruncatedSVD transformer with K (the truncated
 // dimensionality) being set to the specified value k
 func NewTruncatedSVD(k int) *TruncatedSVD {
 	return &TruncatedSVD{K: k}
 }
 
+// NewRandomizedSVD creates a new TruncatedSVD transformer with K (the truncated
+// dimensionality) being set to the specified value k, configured to approximate
+// the factorisation using the randomized algorithm of Halko, Martinsson and Tropp
+// rather than a full, dense SVD. oversampling controls the size of the random
+// sketch used (k+oversampling columns, defaulting to 10 if <= 0) and
+// powerIterations controls the number of power iterations used to improve
+// accuracy for matrices with slowly decaying singular values.
+func NewRandomizedSVD(k, oversampling, powerIterations int) *TruncatedSVD {
+	return &TruncatedSVD{
+		K:               k,
+		Method:          RandomizedSVD,
+		Oversampling:    oversampling,
+		PowerIterations: powerIterations,
+	}
+}
+
 // Fit performs the SVD factorisation on the input training data matrix, mat and
 // stores the output term matrix as a transform to apply to matrices in the Transform matrix.
 func (t *TruncatedSVD) Fit(mat mat.Matrix) Transformer {
@@ -59,6 +135,10 @@ func (t *TruncatedSVD) Transform(m mat.Matrix) (mat.Matrix, error) {
 // used to fit the model i.e. the model is fitted on the fly to the test data.
 // The returned matrix is a dense matrix type.
 func (t *TruncatedSVD) FitTransform(m mat.Matrix) (mat.Matrix, error) {
+	if t.Method == RandomizedSVD {
+		return t.fitTransformRandomized(m)
+	}
+
 	var svd mat.SVD
 	if ok := svd.Factorize(m, mat.SVDThin); !ok {
 		return nil, fmt.Errorf("Failed SVD Factorisation of working matrix")
@@ -73,6 +153,8 @@ func (t *TruncatedSVD) FitTransform(m mat.Matrix) (mat.Matrix, error) {
 	vk := v.Slice(0, c, 0, k)
 
 	t.Components = uk.(*mat.Dense)
+	t.sigma = append([]float64{}, s[:k]...)
+	t.v = mat.DenseCopyOf(vk)
 
 	// multiply Sigma by transpose of V. As sigma is a symmetrical (square) diagonal matrix it is
 	// more efficient to simply multiply each element from the array of diagonal values with each
@@ -85,6 +167,210 @@ func (t *TruncatedSVD) FitTransform(m mat.Matrix) (mat.Matrix, error) {
 	return &product, nil
 }
 
+// fitTransformRandomized approximates the truncated SVD of m using the randomized
+// range finder algorithm of Halko, Martinsson and Tropp:
+//
+//  1. draw a random Gaussian matrix Omega of shape cols(m) x (K+p)
+//  2. form the sketch Y = m * Omega, multiplying directly against m so that no
+//     dense copy of m is required even where m is sparse
+//  3. optionally refine Y with PowerIterations power iterations, re-orthonormalising
+//     between each via QR to avoid loss of precision
+//  4. QR-factorise Y to obtain an orthonormal basis Q for the range of m
+//  5. form the small matrix B = Q^T * m and take its full SVD
+//  6. lift the left singular vectors of B back into the original space via Q and
+//     truncate to K, giving the same Components basis FullSVD would produce
+func (t *TruncatedSVD) fitTransformRandomized(m mat.Matrix) (mat.Matrix, error) {
+	rows, cols := m.Dims()
+
+	p := t.Oversampling
+	if p <= 0 {
+		p = defaultOversampling
+	}
+
+	// the range basis Q derived from Y = m*Omega can have at most min(rows, cols)
+	// columns, so cap l there too - otherwise a short/wide m (rows < K+p) yields
+	// a Y with more columns than rows and thinQR's underlying gonum QR
+	// factorisation panics.
+	l := minimum(t.K+p, rows, cols)
+
+	omegaData := make([]float64, cols*l)
+	for i := range omegaData {
+		omegaData[i] = rand.NormFloat64()
+	}
+	omega := mat.NewDense(cols, l, omegaData)
+
+	var y mat.Dense
+	y.Mul(m, omega)
+
+	for i := 0; i < t.PowerIterations; i++ {
+		q := orthonormalBasis(&y)
+
+		var z mat.Dense
+		z.Mul(m.T(), q)
+
+		y.Mul(m, orthonormalBasis(&z))
+	}
+
+	q := orthonormalBasis(&y)
+
+	var b mat.Dense
+	b.Mul(q.T(), m)
+
+	var svd mat.SVD
+	if ok := svd.Factorize(&b, mat.SVDThin); !ok {
+		return nil, fmt.Errorf("Failed SVD Factorisation of randomized sketch matrix")
+	}
+	s, uTilde, vTilde := t.extractSVD(&svd)
+
+	var u mat.Dense
+	u.Mul(q, uTilde)
+
+	r, c := m.Dims()
+	k := minimum(t.K, r, c)
+
+	t.Components = mat.DenseCopyOf(u.Slice(0, r, 0, k))
+	t.sigma = append([]float64{}, s[:k]...)
+	t.v = mat.DenseCopyOf(vTilde.Slice(0, c, 0, k))
+
+	var product mat.Dense
+	product.Apply(func(i, j int, v float64) float64 {
+		return v * s[i]
+	}, vTilde.Slice(0, c, 0, k).T())
+
+	return &product, nil
+}
+
+// PartialFit extends a previously fitted TruncatedSVD with an additional batch of
+// training data, c, updating Components (and the singular values/vectors retained
+// to support further calls to PartialFit) in place without needing to hold the
+// full corpus, or previously seen batches, in memory. It implements Brand's
+// incremental SVD algorithm: the new batch is projected onto the existing basis,
+// the residual orthogonal to that basis is QR-factorised, the resulting small
+// (K+c) x (K+c) matrix is fully SVD'd and used to update and re-truncate the
+// rank K factorisation back to K components.
+//
+// If the receiver has not yet been fitted, PartialFit is equivalent to calling
+// Fit with the supplied batch.
+func (t *TruncatedSVD) PartialFit(c mat.Matrix) OnlineTransformer {
+	if t.Components == nil {
+		if _, err := t.FitTransform(c); err != nil {
+			panic("nlp: Failed to fit truncated SVD because " + err.Error())
+		}
+		return t
+	}
+
+	if err := t.partialFit(c); err != nil {
+		panic("nlp: Failed to partially fit truncated SVD because " + err.Error())
+	}
+
+	return t
+}
+
+func (t *TruncatedSVD) partialFit(c mat.Matrix) error {
+	rows, batchCols := c.Dims()
+	k := len(t.sigma)
+
+	// project the new batch onto the existing basis (L) and factorise the
+	// residual (H) orthogonal to it
+	var l mat.Dense
+	l.Mul(t.Components.T(), c)
+
+	var projected mat.Dense
+	projected.Mul(t.Components, &l)
+
+	var h mat.Dense
+	h.Sub(c, &projected)
+
+	j, upperK := thinQR(&h)
+
+	// form the (k+batchCols) x (k+batchCols) middle matrix [[Sigma, L], [0, K]]
+	size := k + batchCols
+	middle := mat.NewDense(size, size, nil)
+	for i := 0; i < k; i++ {
+		middle.Set(i, i, t.sigma[i])
+		for col := 0; col < batchCols; col++ {
+			middle.Set(i, k+col, l.At(i, col))
+		}
+	}
+	for row := 0; row < batchCols; row++ {
+		for col := 0; col < batchCols; col++ {
+			middle.Set(k+row, k+col, upperK.At(row, col))
+		}
+	}
+
+	var svd mat.SVD
+	if ok := svd.Factorize(middle, mat.SVDThin); !ok {
+		return fmt.Errorf("Failed SVD Factorisation of incremental update matrix")
+	}
+	s, up, vp := t.extractSVD(&svd)
+
+	// lift the updated left singular vectors back into the original term space
+	// via [U J], the concatenation of the existing basis and the new residual
+	// basis
+	uj := mat.NewDense(rows, size, nil)
+	for i := 0; i < rows; i++ {
+		for col := 0; col < k; col++ {
+			uj.Set(i, col, t.Components.At(i, col))
+		}
+		for col := 0; col < batchCols; col++ {
+			uj.Set(i, k+col, j.At(i, col))
+		}
+	}
+
+	var uNew mat.Dense
+	uNew.Mul(uj, up)
+
+	newK := minimum(t.K, rows, size)
+	t.Components = mat.DenseCopyOf(uNew.Slice(0, rows, 0, newK))
+	t.sigma = append([]float64{}, s[:newK]...)
+
+	// extend V similarly via [[V 0], [0 I]], accounting for the documents seen
+	// in previous batches alongside those in the new one
+	existingRows, _ := t.v.Dims()
+	vBig := mat.NewDense(existingRows+batchCols, size, nil)
+	for i := 0; i < existingRows; i++ {
+		for col := 0; col < k; col++ {
+			vBig.Set(i, col, t.v.At(i, col))
+		}
+	}
+	for i := 0; i < batchCols; i++ {
+		vBig.Set(existingRows+i, k+i, 1)
+	}
+
+	var vNew mat.Dense
+	vNew.Mul(vBig, vp)
+	t.v = mat.DenseCopyOf(vNew.Slice(0, existingRows+batchCols, 0, newK))
+
+	return nil
+}
+
+// orthonormalBasis computes an orthonormal basis for the column space of m via QR
+// factorisation. It is used both to sharpen the sketch matrix between power
+// iterations and to form the final basis for the randomized range finder.
+func orthonormalBasis(m *mat.Dense) *mat.Dense {
+	q, _ := thinQR(m)
+	return q
+}
+
+// thinQR computes the thin (economy size) QR factorisation of the m x n matrix m
+// (m >= n), returning an m x n orthonormal basis q for its column space and the
+// n x n upper triangular factor r such that m = q * r.
+func thinQR(m *mat.Dense) (q, r *mat.Dense) {
+	var qr mat.QR
+	qr.Factorize(m)
+
+	rows, cols := m.Dims()
+
+	var qFull, rFull mat.Dense
+	qr.QTo(&qFull)
+	qr.RTo(&rFull)
+
+	q = mat.DenseCopyOf(qFull.Slice(0, rows, 0, cols))
+	r = mat.DenseCopyOf(rFull.Slice(0, cols, 0, cols))
+
+	return q, r
+}
+
 func minimum(k, m, n int) int {
 	return min(k, min(m, n))
 }
@@ -230,3 +516,192 @@ func (s *SignRandomProjection) Transform(m mat.Matrix) (mat.Matrix, error) {
 func (s *SignRandomProjection) FitTransform(m mat.Matrix) (mat.Matrix, error) {
 	return s.Fit(m).Transform(m)
 }
+
+// Save binary serialises the model and writes it into w. This is useful for persisting
+// a trained model to disk so that it may be loaded (using the Load() method) in another
+// context (e.g. production) for reproducible results.
+func (s SignRandomProjection) Save(w io.Writer) error {
+	var buf [8]byte
+	binary.LittleEndian.PutUint64(buf[:], uint64(s.Bits))
+	if _, err := w.Write(buf[:]); err != nil {
+		return err
+	}
+
+	_, err := s.simHash.matrix().MarshalBinaryTo(w)
+
+	return err
+}
+
+// Load binary deserialises the previously serialised model into the receiver. This is
+// useful for loading a previously trained and saved model from another context
+// (e.g. offline training) for use within another context (e.g. production) for
+// reproducible results. Load should only be performed with trusted data.
+func (s *SignRandomProjection) Load(r io.Reader) error {
+	var n int
+	var buf [8]byte
+	var err error
+	for n < len(buf) && err == nil {
+		var nn int
+		nn, err = r.Read(buf[n:])
+		n += nn
+	}
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	if err != nil {
+		return err
+	}
+	bits := int(binary.LittleEndian.Uint64(buf[:]))
+
+	var hyperplanes mat.Dense
+	if _, err := hyperplanes.UnmarshalBinaryFrom(r); err != nil {
+		return err
+	}
+
+	s.Bits = bits
+	s.simHash = simHashFromMatrix(&hyperplanes)
+
+	return nil
+}
+
+// MinHashProjection represents a transform of a matrix into a lower dimensional
+// space using Locality Sensitive Hashing (LSH) for Jaccard similarity, based on
+// Broder's MinHash algorithm (see MinHash for details). Each column vector in the
+// input matrix is treated as a set (the indices of its non-zero elements) and
+// hashed into a signature of `Bits` minimum hash values. The fraction of matching
+// values between two signatures (measured using pairwise.MinHashSimilarity)
+// approximates the Jaccard similarity of the original sets/vectors, at a fraction
+// of the memory and processing cost of comparing the original (potentially very
+// high dimensional, sparse) vectors directly.
+type MinHashProjection struct {
+	// Bits represents the number of independent hash functions (and hence the
+	// length of the output signature vectors) used for the transformation
+	Bits int
+
+	// minHash is the MinHash LSH (Locality Sensitive Hashing) algorithm used to
+	// perform the transform
+	minHash *MinHash
+}
+
+// NewMinHashProjection constructs a new MinHashProjection transformer to reduce
+// the dimensionality. The transformer uses a number of independent hash functions
+// represented by `bits` and is the dimensionality of the output, transformed
+// matrices.
+func NewMinHashProjection(bits int) *MinHashProjection {
+	return &MinHashProjection{Bits: bits}
+}
+
+// Fit creates the hash functions used to perform the transformation. As MinHash
+// operates on the indices of non-zero elements rather than the values of the
+// input matrix, Fit need not inspect the training data matrix, mat, itself.
+func (s *MinHashProjection) Fit(m mat.Matrix) Transformer {
+	s.minHash = NewMinHash(s.Bits)
+	return s
+}
+
+// Transform applies the transform created in Fit() to the input matrix. The
+// columns in the resulting output matrix will be a low dimensional signature
+// representation of the columns within the original i.e. a hash or fingerprint
+// that can be quickly and efficiently compared with other similar vectors using
+// pairwise.MinHashSimilarity to approximate Jaccard similarity between the
+// vectors of the original space. The returned matrix is a dense matrix type.
+func (s *MinHashProjection) Transform(m mat.Matrix) (mat.Matrix, error) {
+	if v, isVec := m.(mat.Vector); isVec {
+		return s.minHash.Hash(v), nil
+	}
+
+	_, cols := m.Dims()
+
+	sigs := mat.NewDense(s.Bits, cols, nil)
+	ColDo(m, func(j int, v mat.Vector) {
+		sigs.SetCol(j, s.minHash.Hash(v).RawVector().Data)
+	})
+	return sigs, nil
+}
+
+// FitTransform is approximately equivalent to calling Fit() followed by
+// Transform() on the same matrix. This is a useful shortcut where separate
+// training data is not being used to fit the model i.e. the model is fitted on
+// the fly to the test data. The returned matrix is a dense matrix type.
+func (s *MinHashProjection) FitTransform(m mat.Matrix) (mat.Matrix, error) {
+	return s.Fit(m).Transform(m)
+}
+
+// defaultStableWidth is the bucket width used by StableRandomProjection when
+// Width is left unset.
+const defaultStableWidth = 4.0
+
+// StableRandomProjection represents a transform of a matrix into a lower
+// dimensional space using Locality Sensitive Hashing (LSH) for Euclidean (L2)
+// distance, based on the p-stable distribution hashing scheme of Datar, Immorlica,
+// Indyk and Mirrokni (see StableHash for details). Each column vector in the input
+// matrix is projected onto `Bits` random directions drawn from a p-stable
+// distribution and discretised into buckets of width `Width`. The fraction of
+// matching bucket indices between two signatures (measured using
+// pairwise.StableHashSimilarity) decreases monotonically with the Euclidean
+// distance between the original vectors.
+type StableRandomProjection struct {
+	// Bits represents the number of independent random projections (and hence
+	// the length of the output signature vectors) used for the transformation
+	Bits int
+
+	// Width is the bucket width used to discretise the projected values.
+	// Smaller values increase sensitivity to small distances at the cost of
+	// reduced stability across hash functions. Defaults to 4.0 if <= 0.
+	Width float64
+
+	// stableHash is the StableHash LSH (Locality Sensitive Hashing) algorithm
+	// used to perform the transform
+	stableHash *StableHash
+}
+
+// NewStableRandomProjection constructs a new StableRandomProjection transformer
+// to reduce the dimensionality. The transformer uses a number of random
+// projections represented by `bits` (the dimensionality of the output,
+// transformed matrices) and discretises the projected values into buckets of the
+// specified width.
+func NewStableRandomProjection(bits int, width float64) *StableRandomProjection {
+	return &StableRandomProjection{Bits: bits, Width: width}
+}
+
+// Fit creates the random projections from the input training data matrix, mat,
+// and stores them as a transform to apply to matrices.
+func (s *StableRandomProjection) Fit(m mat.Matrix) Transformer {
+	width := s.Width
+	if width <= 0 {
+		width = defaultStableWidth
+	}
+	s.Width = width
+
+	rows, _ := m.Dims()
+	s.stableHash = NewStableHash(s.Bits, rows, width)
+	return s
+}
+
+// Transform applies the transform created in Fit() to the input matrix. The
+// columns in the resulting output matrix will be a low dimensional signature
+// representation of the columns within the original i.e. a hash or fingerprint
+// that can be quickly and efficiently compared with other similar vectors using
+// pairwise.StableHashSimilarity to approximate proximity, in Euclidean space, of
+// the vectors of the original space. The returned matrix is a dense matrix type.
+func (s *StableRandomProjection) Transform(m mat.Matrix) (mat.Matrix, error) {
+	if v, isVec := m.(mat.Vector); isVec {
+		return s.stableHash.Hash(v), nil
+	}
+
+	_, cols := m.Dims()
+
+	sigs := mat.NewDense(s.Bits, cols, nil)
+	ColDo(m, func(j int, v mat.Vector) {
+		sigs.SetCol(j, s.stableHash.Hash(v).RawVector().Data)
+	})
+	return sigs, nil
+}
+
+// FitTransform is approximately equivalent to calling Fit() followed by
+// Transform() on the same matrix. This is a useful shortcut where separate
+// training data is not being used to fit the model i.e. the model is fitted on
+// the fly to the test data. The returned matrix is a dense matrix type.
+func (s *StableRandomProjection) FitTransform(m mat.Matrix) (mat.Matrix, error) {
+	return s.Fit(m).Transform(m)
+}
@@ -73,6 +73,190 @@ func TestTruncatedSVDFitTransform(t *testing.T) {
 	}
 }
 
+func TestRandomizedSVDFitTransform(t *testing.T) {
+	var tests = []struct {
+		m            int
+		n            int
+		input        []float64
+		k            int
+		oversampling int
+		powerIter    int
+	}{
+		{
+			m: 6, n: 4,
+			input: []float64{
+				1, 3, 5, 2,
+				8, 1, 0, 0,
+				2, 1, 0, 1,
+				0, 0, 0, 0,
+				0, 0, 0, 1,
+				0, 1, 0, 0,
+			},
+			k:            2,
+			oversampling: 10,
+			powerIter:    4,
+		},
+		{
+			// cols (40) is far larger than k+oversampling (8), so Omega is a
+			// tall, rank-deficient sketch of the column space rather than a
+			// square, full-rank one - exercising the oversampling, power
+			// iteration and truncation steps the first (square) fixture
+			// above cannot reach.
+			m:            50,
+			n:            40,
+			input:        lowRankMatrix(50, 40, 3, 7).RawMatrix().Data,
+			k:            3,
+			oversampling: 5,
+			powerIter:    4,
+		},
+		{
+			// rows (3) is smaller than k+oversampling (12), so the sketch
+			// width l must be capped at rows rather than cols alone - this
+			// short/wide shape previously panicked in thinQR's underlying
+			// gonum QR factorisation ("matrix has more columns than rows").
+			m:            3,
+			n:            50,
+			input:        lowRankMatrix(3, 50, 2, 11).RawMatrix().Data,
+			k:            2,
+			oversampling: 10,
+			powerIter:    2,
+		},
+	}
+
+	for _, test := range tests {
+		input := mat.NewDense(test.m, test.n, test.input)
+
+		exact := NewTruncatedSVD(test.k)
+		expResult, err := exact.FitTransform(input)
+		if err != nil {
+			t.Fatalf("Failed exact Truncated SVD transform caused by %v", err)
+		}
+
+		approx := NewRandomizedSVD(test.k, test.oversampling, test.powerIter)
+		result, err := approx.FitTransform(input)
+		if err != nil {
+			t.Fatalf("Failed Randomized SVD transform caused by %v", err)
+		}
+
+		// the randomized algorithm may recover singular vectors with
+		// flipped sign relative to the deterministic algorithm, so align
+		// signs row by row before comparing magnitudes
+		aligned := mat.DenseCopyOf(result)
+		rows, cols := aligned.Dims()
+		for i := 0; i < rows; i++ {
+			var dot float64
+			for j := 0; j < cols; j++ {
+				dot += expResult.At(i, j) * aligned.At(i, j)
+			}
+			if dot < 0 {
+				for j := 0; j < cols; j++ {
+					aligned.Set(i, j, -aligned.At(i, j))
+				}
+			}
+		}
+
+		if !mat.EqualApprox(expResult, aligned, 0.1) {
+			t.Logf("Expected matrix (within tolerance of exact SVD): \n%v\n but found: \n%v\n",
+				mat.Formatted(expResult),
+				mat.Formatted(aligned))
+			t.Fail()
+		}
+	}
+}
+
+// lowRankMatrix deterministically builds a rows x cols matrix of effective
+// rank `rank` (rows, cols >> rank) by multiplying two random factors, for use
+// as a fixture against which randomized SVD's approximation of a genuinely
+// rank-deficient matrix can be tested. seed fixes the factors so the fixture
+// is reproducible between test runs.
+func lowRankMatrix(rows, cols, rank int, seed int64) *mat.Dense {
+	src := rand.New(rand.NewSource(seed))
+
+	uData := make([]float64, rows*rank)
+	for i := range uData {
+		uData[i] = src.NormFloat64()
+	}
+	vData := make([]float64, rank*cols)
+	for i := range vData {
+		vData[i] = src.NormFloat64()
+	}
+
+	var m mat.Dense
+	m.Mul(mat.NewDense(rows, rank, uData), mat.NewDense(rank, cols, vData))
+	return &m
+}
+
+func TestTruncatedSVDPartialFit(t *testing.T) {
+	var tests = []struct {
+		m      int
+		n      int
+		batch1 []float64
+		batch2 []float64
+		k      int
+	}{
+		{
+			m: 6, n: 2,
+			batch1: []float64{
+				1, 3,
+				8, 1,
+				2, 1,
+				0, 0,
+				0, 0,
+				0, 1,
+			},
+			batch2: []float64{
+				5, 2,
+				0, 0,
+				0, 1,
+				0, 0,
+				0, 1,
+				0, 0,
+			},
+			k: 2,
+		},
+	}
+
+	for _, test := range tests {
+		batch1 := mat.NewDense(test.m, test.n, test.batch1)
+		batch2 := mat.NewDense(test.m, test.n, test.batch2)
+
+		concat := mat.NewDense(test.m, 2*test.n, nil)
+		concat.Slice(0, test.m, 0, test.n).(*mat.Dense).Copy(batch1)
+		concat.Slice(0, test.m, test.n, 2*test.n).(*mat.Dense).Copy(batch2)
+
+		batchTransformer := NewTruncatedSVD(test.k)
+		batchTransformer.Fit(concat)
+
+		streamingTransformer := NewTruncatedSVD(test.k)
+		streamingTransformer.Fit(batch1)
+		streamingTransformer.PartialFit(batch2)
+
+		// the incremental algorithm may recover components with flipped sign
+		// relative to a single batch factorisation of the same data, so align
+		// signs column by column before comparing magnitudes
+		aligned := mat.DenseCopyOf(streamingTransformer.Components)
+		rows, cols := aligned.Dims()
+		for j := 0; j < cols; j++ {
+			var dot float64
+			for i := 0; i < rows; i++ {
+				dot += batchTransformer.Components.At(i, j) * aligned.At(i, j)
+			}
+			if dot < 0 {
+				for i := 0; i < rows; i++ {
+					aligned.Set(i, j, -aligned.At(i, j))
+				}
+			}
+		}
+
+		if !mat.EqualApprox(batchTransformer.Components, aligned, 0.01) {
+			t.Logf("Expected components (from single batch fit): \n%v\n but found (from incremental fit): \n%v\n",
+				mat.Formatted(batchTransformer.Components),
+				mat.Formatted(aligned))
+			t.Fail()
+		}
+	}
+}
+
 func TestTruncatedSVDSaveLoad(t *testing.T) {
 	var transforms = []struct {
 		wanted *TruncatedSVD
@@ -180,3 +364,155 @@ func TestSignRandomProjections(t *testing.T) {
 		}
 	}
 }
+
+func TestMinHashProjections(t *testing.T) {
+	tests := []struct {
+		rows int
+		cols int
+		bits int
+	}{
+		{rows: 100, cols: 200, bits: 1024},
+	}
+
+	for ti, test := range tests {
+		// Given an input matrix of 0/1 values (representing set membership)
+		// and a query matching one column
+		matrix := mat.NewDense(test.rows, test.cols, nil)
+		for i := 0; i < test.rows; i++ {
+			for j := 0; j < test.cols; j++ {
+				if rand.Float64() < 0.3 {
+					matrix.Set(i, j, 1)
+				}
+			}
+		}
+
+		query := matrix.ColView(0)
+
+		// When transformed using MinHash
+		transformer := NewMinHashProjection(test.bits)
+		reducedDimMatrix, err := transformer.FitTransform(matrix)
+		if err != nil {
+			t.Errorf("Failed to transform matrix because %v\n", err)
+		}
+		m := reducedDimMatrix.(*mat.Dense)
+
+		reducedDimQuery, err := transformer.Transform(query)
+		if err != nil {
+			t.Errorf("Failed to transform query because %v\n", err)
+		}
+		q := reducedDimQuery.(*mat.VecDense)
+
+		var culmDiff float64
+		for i := 0; i < test.cols; i++ {
+			jacSim := pairwise.JaccardSimilarity(query, matrix.ColView(i))
+			lshSim := pairwise.MinHashSimilarity(q, m.ColView(i))
+
+			if i == 0 {
+				if math.Abs(jacSim-lshSim) >= 0.02 {
+					t.Errorf("Test %d: Expected matching similarity but found %.10f (Jaccard) and %.10f (MinHash)\n", ti, jacSim, lshSim)
+				}
+			}
+
+			culmDiff += math.Abs(lshSim - jacSim)
+		}
+		avgDiff := culmDiff / float64(test.cols)
+
+		// Then output matrix should be of specified length and average
+		// difference between Jaccard and MinHash similarities should be small
+		r, c := m.Dims()
+		if r != test.bits || c != test.cols {
+			t.Errorf("Test %d: Expected output matrix to be %dx%d but was %dx%d\n", ti, test.bits, test.cols, r, c)
+		}
+		if avgDiff >= 0.05 {
+			t.Errorf("Test %d: Expected average difference between vector spaces < 0.05 but was %f\n", ti, avgDiff)
+		}
+	}
+}
+
+func TestStableRandomProjections(t *testing.T) {
+	tests := []struct {
+		dim   int
+		bits  int
+		width float64
+	}{
+		{dim: 50, bits: 512, width: 4.0},
+	}
+
+	for ti, test := range tests {
+		// Given a base vector, a second vector very close to it and a third
+		// vector a long way away
+		base := make([]float64, test.dim)
+		near := make([]float64, test.dim)
+		far := make([]float64, test.dim)
+		for i := range base {
+			base[i] = rand.Float64()
+			near[i] = base[i] + rand.NormFloat64()*0.01
+			far[i] = base[i] + 10
+		}
+
+		matrix := mat.NewDense(test.dim, 3, nil)
+		matrix.SetCol(0, base)
+		matrix.SetCol(1, near)
+		matrix.SetCol(2, far)
+
+		// When transformed using p-stable LSH
+		transformer := NewStableRandomProjection(test.bits, test.width)
+		reduced, err := transformer.FitTransform(matrix)
+		if err != nil {
+			t.Fatalf("Test %d: Failed to transform matrix because %v\n", ti, err)
+		}
+		m := reduced.(*mat.Dense)
+
+		nearSim := pairwise.StableHashSimilarity(m.ColView(0), m.ColView(1))
+		farSim := pairwise.StableHashSimilarity(m.ColView(0), m.ColView(2))
+
+		// Then the close pair of vectors should hash to matching buckets far
+		// more often than the distant pair
+		if nearSim <= farSim {
+			t.Errorf("Test %d: Expected near vectors to have higher StableHashSimilarity (%f) than far vectors (%f)\n", ti, nearSim, farSim)
+		}
+	}
+}
+
+func TestSignRandomProjectionSaveLoad(t *testing.T) {
+	rows, cols, bits := 20, 10, 64
+
+	matrix := mat.NewDense(rows, cols, nil)
+	for i := 0; i < rows; i++ {
+		for j := 0; j < cols; j++ {
+			matrix.Set(i, j, rand.Float64())
+		}
+	}
+	query := matrix.ColView(0)
+
+	transformer := NewSignRandomProjection(bits)
+	transformer.Fit(matrix)
+
+	expResult, err := transformer.Transform(query)
+	if err != nil {
+		t.Fatalf("Failed to transform query because %v\n", err)
+	}
+
+	buf := new(bytes.Buffer)
+	if err := transformer.Save(buf); err != nil {
+		t.Fatalf("Error encoding: %v\n", err)
+	}
+
+	var loaded SignRandomProjection
+	if err := loaded.Load(buf); err != nil {
+		t.Fatalf("Error decoding: %v\n", err)
+	}
+
+	result, err := loaded.Transform(query)
+	if err != nil {
+		t.Fatalf("Failed to transform query with loaded transformer because %v\n", err)
+	}
+
+	if !mat.Equal(expResult, result) {
+		t.Errorf("Expected transformed query to be identical after Save/Load, but found %v vs %v\n",
+			expResult, result)
+	}
+	if loaded.Bits != transformer.Bits {
+		t.Errorf("Bits value mismatch: Wanted %d but got %d\n", transformer.Bits, loaded.Bits)
+	}
+}
@@ -1,6 +1,8 @@
 package nlp
 
 import (
+	"math"
+	"math/bits"
 	"math/rand"
 
 	"github.com/james-bowman/sparse"
@@ -60,3 +62,157 @@ func (h *SimHash) Hash(v mat.Vector) *sparse.BinaryVec {
 	}
 	return sig
 }
+
+// matrix assembles the random hyperplanes into a single dense bits x dim matrix,
+// primarily to support serialisation via SignRandomProjection.Save.
+func (h *SimHash) matrix() *mat.Dense {
+	bits := len(h.hyperplanes)
+	dim := h.hyperplanes[0].Len()
+
+	m := mat.NewDense(bits, dim, nil)
+	for i, hyperplane := range h.hyperplanes {
+		m.SetRow(i, hyperplane.RawVector().Data)
+	}
+	return m
+}
+
+// simHashFromMatrix reconstructs a SimHash from a dense bits x dim matrix of
+// hyperplanes, as produced by SimHash.matrix, primarily to support
+// deserialisation via SignRandomProjection.Load.
+func simHashFromMatrix(m *mat.Dense) *SimHash {
+	bits, dim := m.Dims()
+
+	hyperplanes := make([]*mat.VecDense, bits)
+	for i := 0; i < bits; i++ {
+		row := make([]float64, dim)
+		mat.Row(row, i, m)
+		hyperplanes[i] = mat.NewVecDense(dim, row)
+	}
+	return &SimHash{hyperplanes: hyperplanes}
+}
+
+// minHashPrime is a Mersenne prime larger than any index encountered in
+// practice, used as the modulus for the universal hash functions underlying
+// MinHash. It is kept below 2^53 (rather than using a larger Mersenne prime
+// such as 2^61-1) so that every hash value it produces is exactly
+// representable as a float64, matching the signature's storage as a
+// mat.VecDense without silently rounding values.
+const minHashPrime = (1 << 31) - 1
+
+// MinHash implements the MinHash Locality Sensitive Hashing (LSH) algorithm for
+// Jaccard similarity based on the work of Andrei Z. Broder. A set of independent
+// universal hash functions are applied to the indices of the non-zero elements of
+// an input vector (treating it as a set) and, for each hash function, the minimum
+// resulting value over the set is retained. The probability that two vectors agree
+// on the minimum hash value for a given hash function is equal to the Jaccard
+// similarity of the sets they represent, so the fraction of agreeing values across
+// many hash functions (measured using pairwise.MinHashSimilarity) approximates
+// Jaccard similarity between the original vectors.
+//
+// Broder, Andrei Z. "On the resemblance and containment of documents" in
+// Proceedings. Compression and Complexity of SEQUENCES 1997, p. 21-29.
+// https://www.cs.princeton.edu/courses/archive/spring13/cos598C/broder97resemblance.pdf
+type MinHash struct {
+	a, b []uint64
+}
+
+// NewMinHash constructs a new MinHash creating size independent universal hash
+// functions of the form h(x) = (a*x + b) mod p used to simulate size independent
+// random permutations of the indices of the input vectors.
+func NewMinHash(size int) *MinHash {
+	a := make([]uint64, size)
+	b := make([]uint64, size)
+	for i := range a {
+		a[i] = uint64(rand.Int63n(minHashPrime-1)) + 1
+		b[i] = uint64(rand.Int63n(minHashPrime))
+	}
+	return &MinHash{a: a, b: b}
+}
+
+// Hash accepts a Vector and outputs a dense signature vector of length size
+// (the number of hash functions used to construct the MinHash), each element
+// of which is the minimum hash value across the non-zero elements of v for the
+// corresponding hash function.
+func (h *MinHash) Hash(v mat.Vector) *mat.VecDense {
+	size := len(h.a)
+	sig := make([]float64, size)
+	for i := range sig {
+		sig[i] = math.MaxUint64
+	}
+
+	dim := v.Len()
+	for idx := 0; idx < dim; idx++ {
+		if v.AtVec(idx) == 0 {
+			continue
+		}
+		for i := range h.a {
+			// a*(idx+1) can overflow 64 bits for large indices, which would
+			// otherwise wrap the product mod 2^64 before reducing it mod p,
+			// breaking the (a*x+b) mod p universal-hash guarantee. Carry out
+			// the multiply and add in 128 bits and reduce that, rather than
+			// reducing an already-wrapped 64-bit product.
+			hi, lo := bits.Mul64(h.a[i], uint64(idx+1))
+			lo, carry := bits.Add64(lo, h.b[i], 0)
+			hi += carry
+			hv := float64(bits.Rem64(hi, lo, minHashPrime))
+			if hv < sig[i] {
+				sig[i] = hv
+			}
+		}
+	}
+	return mat.NewVecDense(size, sig)
+}
+
+// StableHash implements the p-stable distribution Locality Sensitive Hashing (LSH)
+// algorithm for Euclidean (L2) distance based on the work of Datar, Immorlica,
+// Indyk and Mirrokni. A set of random projections drawn from a p-stable
+// distribution (the Gaussian distribution is 2-stable) are applied to the input
+// vector and the projected values are then discretised into buckets of width w,
+// each bucket boundary offset by a random value drawn uniformly from [0, w). The
+// probability that two vectors hash to the same bucket for a given projection
+// decreases monotonically as the Euclidean distance between them increases, so the
+// fraction of matching bucket indices across many projections (measured using
+// pairwise.StableHashSimilarity) approximates proximity of the original vectors in
+// L2 space.
+//
+// Datar, Mayur et al. "Locality-sensitive hashing scheme based on p-stable
+// distributions" in Proceedings of the twentieth annual symposium on Computational
+// geometry - SCG '04, 2004, p. 253.
+// http://www.cs.princeton.edu/courses/archive/spring05/cos598E/bib/p253-datar.pdf
+type StableHash struct {
+	projections []*mat.VecDense
+	offsets     []float64
+	width       float64
+}
+
+// NewStableHash constructs a new StableHash creating bits random projections
+// (drawn from the Gaussian distribution) of input vectors of length dim, each
+// discretised into buckets of the specified width.
+func NewStableHash(bits int, dim int, width float64) *StableHash {
+	projections := make([]*mat.VecDense, bits)
+	offsets := make([]float64, bits)
+
+	for i := 0; i < bits; i++ {
+		p := make([]float64, dim)
+		for j := range p {
+			p[j] = rand.NormFloat64()
+		}
+		projections[i] = mat.NewVecDense(dim, p)
+		offsets[i] = rand.Float64() * width
+	}
+	return &StableHash{projections: projections, offsets: offsets, width: width}
+}
+
+// Hash accepts a Vector and outputs a dense signature vector of length bits (the
+// number of random projections used to construct the StableHash), each element of
+// which is the index of the bucket v falls into for the corresponding projection.
+// This method will panic if the input vector is of a different length than the
+// dim parameter used when constructing the StableHash.
+func (h *StableHash) Hash(v mat.Vector) *mat.VecDense {
+	bits := len(h.projections)
+	sig := make([]float64, bits)
+	for i := 0; i < bits; i++ {
+		sig[i] = math.Floor((sparse.Dot(v, h.projections[i]) + h.offsets[i]) / h.width)
+	}
+	return mat.NewVecDense(bits, sig)
+}
@@ -0,0 +1,256 @@
+package nlp
+
+import (
+	"container/heap"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/james-bowman/nlp/measures/pairwise"
+	"github.com/james-bowman/sparse"
+	"gonum.org/v1/gonum/mat"
+)
+
+// Neighbour represents a matching item returned by LSHIndex.Query for nearest
+// neighbour similarity searches. It contains both the ID of the matching item
+// and its similarity to the queried vector (as computed by the index's
+// configured pairwise.Comparer, where a higher value indicates greater
+// similarity).
+type Neighbour struct {
+	ID         string
+	Similarity float64
+}
+
+// neighbourHeap is a min heap (priority queue) used to compile the top-k
+// matches whilst performing nearest neighbour similarity searches.
+type neighbourHeap []Neighbour
+
+func (h neighbourHeap) Len() int            { return len(h) }
+func (h neighbourHeap) Less(i, j int) bool  { return h[i].Similarity < h[j].Similarity }
+func (h neighbourHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *neighbourHeap) Push(x interface{}) { *h = append(*h, x.(Neighbour)) }
+func (h *neighbourHeap) Pop() interface{} {
+	old := *h
+	n := len(old)
+	x := old[n-1]
+	*h = old[:n-1]
+	return x
+}
+
+// Hasher is satisfied by any already-fitted Transformer whose Transform method
+// maps an input vector to a fixed-length bit signature (a *sparse.BinaryVec),
+// such as SignRandomProjection. LSHIndex uses a Hasher to produce the
+// fingerprints it buckets and queries by.
+type Hasher interface {
+	Transform(m mat.Matrix) (mat.Matrix, error)
+}
+
+// LSHIndex is a banded, multi-probe Locality Sensitive Hashing (LSH) based
+// index supporting Approximate Nearest Neighbour (ANN) search over bit
+// signature fingerprints produced by a Hasher (e.g. SignRandomProjection).
+// Each indexed vector is hashed into a fixed-length bit signature and then
+// split into L bands of R contiguous bits, each band being stored in its own
+// hash table; two items are considered candidate neighbours as soon as any
+// one of their L bands collides in its corresponding table. This greatly
+// narrows the search space versus comparing against every indexed vector,
+// trading some recall for query performance, whilst still allowing that
+// trade-off to be tuned via L and R (more bands improve recall at the cost of
+// more storage and more candidates to rerank; more bits per band reduce the
+// rate of false positive collisions). Query reranks the resulting candidates
+// using the exact similarity of their original (un-hashed) vectors so the
+// approximation only affects which candidates are considered, not how they
+// are ranked.
+//
+// This banding technique is based on the Classic LSH scheme of A. Gionis et
+// al, using multiple hash tables (bands) to improve recall for similar items
+// that would otherwise hash to neighbouring, but distinct, buckets within a
+// single table.
+//
+// A. Gionis, P. Indyk, and R. Motwani, "Similarity Search in High Dimensions via
+// Hashing," VLDB '99 Proc. 25th Int. Conf. Very Large Data Bases, vol. 99, no. 1,
+// pp. 518-529, 1999.
+// http://www.cs.princeton.edu/courses/archive/spring13/cos598C/Gionis.pdf
+type LSHIndex struct {
+	// L is the number of bands (hash tables) the signature is split into.
+	L int
+
+	// R is the number of bits per band.
+	R int
+
+	hasher   Hasher
+	distance pairwise.Comparer
+
+	lock     sync.RWMutex
+	bands    []map[uint64][]string
+	bandKeys map[string][]uint64
+	vectors  map[string]mat.Vector
+}
+
+// maxBandBits is the largest number of bits a single band can hold, since
+// each band key is packed into a uint64 by bandKeysForSignature.
+const maxBandBits = 64
+
+// NewLSHIndex creates a new, empty LSHIndex using the supplied, already-fitted
+// Hasher to hash indexed/queried vectors into bit signatures of length l*r,
+// split into l bands of r bits each. Candidates returned by the banded LSH
+// scheme are reranked using the supplied pairwise distance metric, applied to
+// the original (un-hashed) vectors, to recover the true top-k nearest
+// neighbours amongst the candidates. r must be no greater than 64, as each
+// band's bits are packed into a uint64 key; NewLSHIndex panics otherwise.
+func NewLSHIndex(l, r int, hasher Hasher, distance pairwise.Comparer) *LSHIndex {
+	if r > maxBandBits {
+		panic(fmt.Sprintf("nlp: LSHIndex band width r (%d) cannot exceed %d bits, the capacity of the uint64 band key", r, maxBandBits))
+	}
+
+	bands := make([]map[uint64][]string, l)
+	for i := range bands {
+		bands[i] = make(map[uint64][]string)
+	}
+
+	return &LSHIndex{
+		L:        l,
+		R:        r,
+		hasher:   hasher,
+		distance: distance,
+		bands:    bands,
+		bandKeys: make(map[string][]uint64),
+		vectors:  make(map[string]mat.Vector),
+	}
+}
+
+// Index hashes the supplied vector and adds it, along with its associated id,
+// to the index. Indexing a vector under an id that already exists in the
+// index replaces the previously indexed vector for that id.
+func (idx *LSHIndex) Index(id string, vec mat.Vector) {
+	keys := idx.bandKeysForSignature(idx.hash(vec))
+
+	idx.lock.Lock()
+	defer idx.lock.Unlock()
+
+	if _, exists := idx.vectors[id]; exists {
+		idx.remove(id)
+	}
+
+	idx.vectors[id] = vec
+	idx.bandKeys[id] = keys
+	for i, key := range keys {
+		idx.bands[i][key] = append(idx.bands[i][key], id)
+	}
+}
+
+// Query searches for the top-k approximate nearest neighbours to vec in the
+// index, returned in descending order of similarity. The method may return
+// fewer than k neighbours if fewer than k candidates are found by the
+// underlying LSH banding scheme.
+func (idx *LSHIndex) Query(vec mat.Vector, k int) []Neighbour {
+	keys := idx.bandKeysForSignature(idx.hash(vec))
+
+	idx.lock.RLock()
+	defer idx.lock.RUnlock()
+
+	candidates := make(map[string]struct{})
+	for i, key := range keys {
+		for _, id := range idx.bands[i][key] {
+			candidates[id] = struct{}{}
+		}
+	}
+
+	results := make(neighbourHeap, 0, k)
+	for id := range candidates {
+		sim := idx.distance(vec, idx.vectors[id])
+		if len(results) < k {
+			heap.Push(&results, Neighbour{ID: id, Similarity: sim})
+			continue
+		}
+		if sim > results[0].Similarity {
+			heap.Pop(&results)
+			heap.Push(&results, Neighbour{ID: id, Similarity: sim})
+		}
+	}
+
+	sorted := make([]Neighbour, len(results))
+	copy(sorted, results)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Similarity > sorted[j].Similarity })
+
+	return sorted
+}
+
+// Remove removes the vector with the specified id from the index. If no
+// vector is found with the specified id the method will simply do nothing.
+func (idx *LSHIndex) Remove(id string) {
+	idx.lock.Lock()
+	defer idx.lock.Unlock()
+
+	idx.remove(id)
+}
+
+// remove removes the vector with the specified id from the index. The caller
+// must hold idx.lock for writing.
+func (idx *LSHIndex) remove(id string) {
+	keys, exists := idx.bandKeys[id]
+	if !exists {
+		return
+	}
+
+	for i, key := range keys {
+		bucket := idx.bands[i][key]
+		for j, bucketID := range bucket {
+			if bucketID == id {
+				bucket[j] = bucket[len(bucket)-1]
+				bucket = bucket[:len(bucket)-1]
+				if len(bucket) == 0 {
+					delete(idx.bands[i], key)
+				} else {
+					idx.bands[i][key] = bucket
+				}
+				break
+			}
+		}
+	}
+
+	delete(idx.bandKeys, id)
+	delete(idx.vectors, id)
+}
+
+// hash hashes vec using the configured Hasher, panicking if the Hasher does
+// not produce a bit signature (*sparse.BinaryVec) for vector input.
+func (idx *LSHIndex) hash(vec mat.Vector) *sparse.BinaryVec {
+	h, err := idx.hasher.Transform(vec)
+	if err != nil {
+		panic("nlp: Failed to hash vector for LSH indexing because " + err.Error())
+	}
+	sig, ok := h.(*sparse.BinaryVec)
+	if !ok {
+		panic("nlp: Hasher did not produce a bit signature (*sparse.BinaryVec) for the supplied vector")
+	}
+	return sig
+}
+
+// bandKeysForSignature chunks sig into L bands of R bits, each represented as
+// a uint64 key into the corresponding hash table. The method panics if sig is
+// not exactly L*R bits in length.
+//
+// Keys are assembled bit-by-bit via AtVec rather than a bulk accessor such as
+// a hypothetical BinaryVec.SliceToUint64, since AtVec is guaranteed by the
+// mat.Vector interface sig already satisfies, whereas the availability of any
+// wider, sparse.BinaryVec-specific API is dependent on the pinned version of
+// github.com/james-bowman/sparse in use.
+func (idx *LSHIndex) bandKeysForSignature(sig *sparse.BinaryVec) []uint64 {
+	reqLen := idx.L * idx.R
+	if sig.Len() != reqLen {
+		panic(fmt.Sprintf("nlp: Expected hash signature of length %d (L x R) but received %d", reqLen, sig.Len()))
+	}
+
+	keys := make([]uint64, idx.L)
+	for i := range keys {
+		var key uint64
+		for b := 0; b < idx.R; b++ {
+			key <<= 1
+			if sig.AtVec(i*idx.R+b) != 0 {
+				key |= 1
+			}
+		}
+		keys[i] = key
+	}
+	return keys
+}
@@ -0,0 +1,140 @@
+package nlp
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+
+	"github.com/james-bowman/nlp/measures/pairwise"
+	"gonum.org/v1/gonum/mat"
+)
+
+func TestLSHIndexRecall(t *testing.T) {
+	const (
+		dim    = 100
+		numDoc = 200
+		bits   = 64
+		l      = 8
+		r      = 8
+		k      = 10
+	)
+
+	matrix := mat.NewDense(dim, numDoc, nil)
+	for i := 0; i < dim; i++ {
+		for j := 0; j < numDoc; j++ {
+			matrix.Set(i, j, rand.Float64())
+		}
+	}
+
+	hasher := NewSignRandomProjection(bits)
+	hasher.Fit(matrix)
+
+	index := NewLSHIndex(l, r, hasher, pairwise.AngularSimilarity)
+	docs := make([]mat.Vector, numDoc)
+	for j := 0; j < numDoc; j++ {
+		v := mat.VecDenseCopyOf(matrix.ColView(j))
+		docs[j] = v
+		index.Index(fmt.Sprintf("doc%d", j), v)
+	}
+
+	var totalRecall float64
+	const numQueries = 20
+	for q := 0; q < numQueries; q++ {
+		query := docs[q]
+
+		// brute force top-k by exact angular similarity
+		type scored struct {
+			id  string
+			sim float64
+		}
+		exact := make([]scored, numDoc)
+		for j, v := range docs {
+			exact[j] = scored{id: fmt.Sprintf("doc%d", j), sim: pairwise.AngularSimilarity(query, v)}
+		}
+		for i := 0; i < len(exact); i++ {
+			for j := i + 1; j < len(exact); j++ {
+				if exact[j].sim > exact[i].sim {
+					exact[i], exact[j] = exact[j], exact[i]
+				}
+			}
+		}
+		expTop := make(map[string]struct{}, k)
+		for i := 0; i < k; i++ {
+			expTop[exact[i].id] = struct{}{}
+		}
+
+		neighbours := index.Query(query, k)
+		var hits int
+		for _, n := range neighbours {
+			if _, found := expTop[n.ID]; found {
+				hits++
+			}
+		}
+		totalRecall += float64(hits) / float64(k)
+	}
+	avgRecall := totalRecall / numQueries
+
+	// Then the LSH index should recover the majority of the true top-k
+	// nearest neighbours found by brute force/exact search
+	if avgRecall < 0.5 {
+		t.Errorf("Expected average recall@%d >= 0.5 but was %f\n", k, avgRecall)
+	}
+}
+
+func TestLSHIndexRemoveAndReindex(t *testing.T) {
+	const (
+		dim  = 20
+		bits = 32
+		l    = 4
+		r    = 8
+	)
+
+	matrix := mat.NewDense(dim, 3, nil)
+	for i := 0; i < dim; i++ {
+		for j := 0; j < 3; j++ {
+			matrix.Set(i, j, rand.Float64())
+		}
+	}
+
+	hasher := NewSignRandomProjection(bits)
+	hasher.Fit(matrix)
+
+	index := NewLSHIndex(l, r, hasher, pairwise.AngularSimilarity)
+
+	a := mat.VecDenseCopyOf(matrix.ColView(0))
+	b := mat.VecDenseCopyOf(matrix.ColView(1))
+	c := mat.VecDenseCopyOf(matrix.ColView(2))
+
+	index.Index("a", a)
+	index.Index("b", b)
+	index.Index("c", c)
+
+	// Given an indexed item is removed, it should never appear in query results
+	index.Remove("b")
+
+	for _, query := range []mat.Vector{a, b, c} {
+		for _, n := range index.Query(query, 3) {
+			if n.ID == "b" {
+				t.Errorf("Expected removed id 'b' to never appear in query results, but it did\n")
+			}
+		}
+	}
+
+	// Given a removed id is re-indexed against a new vector, queries for that
+	// vector should then return the id again
+	replacement := mat.NewVecDense(dim, nil)
+	for i := 0; i < dim; i++ {
+		replacement.SetVec(i, a.AtVec(i))
+	}
+	index.Index("b", replacement)
+
+	found := false
+	for _, n := range index.Query(replacement, 3) {
+		if n.ID == "b" {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("Expected re-indexed id 'b' to be found when querying for its new vector, but it was not\n")
+	}
+}
@@ -96,6 +96,70 @@ func HammingSimilarity(a, b mat.Vector) float64 {
 	return 1.0 - HammingDistance(a, b)
 }
 
+// JaccardSimilarity treats vectors a and b as sets (the indices of their
+// non-zero elements) and calculates the size of their intersection divided by
+// the size of their union. Possible values range from 0 (disjoint sets) to 1
+// (identical sets). NaN is returned if both vectors are zero length or contain
+// only 0s (an empty union).
+func JaccardSimilarity(a, b mat.Vector) float64 {
+	var intersection, union int
+	n := a.Len()
+	for i := 0; i < n; i++ {
+		inA := a.AtVec(i) != 0
+		inB := b.AtVec(i) != 0
+
+		if inA || inB {
+			union++
+		}
+		if inA && inB {
+			intersection++
+		}
+	}
+
+	if union == 0 {
+		return math.NaN()
+	}
+
+	return float64(intersection) / float64(union)
+}
+
+// JaccardDistance is the complement of JaccardSimilarity in the positive space.
+//
+//	JaccardDistance = 1.0 - JaccardSimilarity
+func JaccardDistance(a, b mat.Vector) float64 {
+	return 1.0 - JaccardSimilarity(a, b)
+}
+
+// matchingFraction calculates the proportion of elements at the same index
+// that are equal between vectors a and b, underlying both MinHashSimilarity
+// and StableHashSimilarity.
+func matchingFraction(a, b mat.Vector) float64 {
+	n := a.Len()
+	var matches float64
+	for i := 0; i < n; i++ {
+		if a.AtVec(i) == b.AtVec(i) {
+			matches++
+		}
+	}
+	return matches / float64(n)
+}
+
+// MinHashSimilarity calculates the proportion of elements that match between 2
+// MinHash signature vectors (as output by MinHashProjection). This proportion
+// is an unbiased estimator of the JaccardSimilarity of the original vectors the
+// signatures were generated from.
+func MinHashSimilarity(a, b mat.Vector) float64 {
+	return matchingFraction(a, b)
+}
+
+// StableHashSimilarity calculates the proportion of elements that match between
+// 2 p-stable LSH signature vectors (as output by StableRandomProjection). This
+// proportion decreases monotonically with the EuclideanDistance of the original
+// vectors the signatures were generated from.
+func StableHashSimilarity(a, b mat.Vector) float64 {
+	return matchingFraction(a, b)
+}
+
 // EuclideanDistance calculates the Euclidean distance (l2 distance) between
 // vectors a and b or more specifically \sqrt{\sum_{i=1}^n (a_i - b_i)^2}
 func EuclideanDistance(a, b mat.Vector) float64 {
@@ -1,6 +1,8 @@
 package nlp
 
 import (
+	"io"
+
 	"gonum.org/v1/gonum/mat"
 )
 
@@ -10,3 +12,19 @@ type Transformer interface {
 	Transform(mat mat.Matrix) (mat.Matrix, error)
 	FitTransform(mat mat.Matrix) (mat.Matrix, error)
 }
+
+// OnlineTransformer is an extension to the Transformer interface that
+// supports online (streaming/mini-batch) training as opposed to just batch.
+type OnlineTransformer interface {
+	Transformer
+	PartialFit(mat.Matrix) OnlineTransformer
+}
+
+// Serialisable provides a common interface for persisting the trained state of
+// a Transformer (e.g. TruncatedSVD or SignRandomProjection) to, and restoring
+// it from, a stream so that models can be trained once (e.g. offline) and then
+// reused across other contexts (e.g. production) without retraining.
+type Serialisable interface {
+	Save(w io.Writer) error
+	Load(r io.Reader) error
+}